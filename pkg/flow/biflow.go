@@ -0,0 +1,127 @@
+/*
+Copyright 2022 Hiroki Shirokura.
+Copyright 2022 Keio University.
+Copyright 2022 Wide Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flow
+
+import "net"
+
+// FiveTuple identifies a direction of a flow. It mirrors the key layout of
+// the eBPF flow-cache map.
+type FiveTuple struct {
+	SourceIP        net.IP
+	DestinationIP   net.IP
+	Protocol        uint8
+	SourcePort      uint16
+	DestinationPort uint16
+}
+
+// reverse returns the 5-tuple of traffic flowing the opposite direction to
+// t, i.e. what a returning packet on the same connection would look like.
+func (t FiveTuple) reverse() FiveTuple {
+	return FiveTuple{
+		SourceIP:        t.DestinationIP,
+		DestinationIP:   t.SourceIP,
+		Protocol:        t.Protocol,
+		SourcePort:      t.DestinationPort,
+		DestinationPort: t.SourcePort,
+	}
+}
+
+// fiveTupleKey is the comparable form of FiveTuple used as a map key: net.IP
+// is a byte slice and isn't comparable, so IPs are normalized to their
+// fixed-size 16-byte representation first.
+type fiveTupleKey struct {
+	sourceIP        [16]byte
+	destinationIP   [16]byte
+	protocol        uint8
+	sourcePort      uint16
+	destinationPort uint16
+}
+
+func (t FiveTuple) key() fiveTupleKey {
+	k := fiveTupleKey{
+		protocol:        t.Protocol,
+		sourcePort:      t.SourcePort,
+		destinationPort: t.DestinationPort,
+	}
+	copy(k.sourceIP[:], t.SourceIP.To16())
+	copy(k.destinationIP[:], t.DestinationIP.To16())
+	return k
+}
+
+// Counters is the subset of per-direction Flow fields a BiflowTracker
+// merges; it mirrors the eBPF map's per-entry value layout.
+type Counters struct {
+	OctetDeltaCount  uint64
+	PacketDeltaCount uint64
+}
+
+// biflowEntry holds the forward and reverse counters accrued so far for one
+// flow, keyed by its forward 5-tuple.
+type biflowEntry struct {
+	Forward Counters
+	Reverse Counters
+}
+
+// BiflowTracker matches returning 5-tuples to an existing forward entry so
+// RFC 5103 biflow templates can emit a single record carrying both forward
+// and reverse counters, instead of the collector seeing two independent
+// unidirectional flows. A flow's direction is decided by whichever 5-tuple
+// Observe sees first; every later Observe call on either that 5-tuple or
+// its reverse accrues onto the same entry. Merge should be called once a
+// flow's lifetime ends, at the same point the forward record is about to
+// be emitted.
+type BiflowTracker struct {
+	byForward map[fiveTupleKey]*biflowEntry
+}
+
+func NewBiflowTracker() *BiflowTracker {
+	return &BiflowTracker{byForward: map[fiveTupleKey]*biflowEntry{}}
+}
+
+// Observe accrues counters seen for 5-tuple t, in whichever direction it
+// is. Traffic matching the reverse of an already-known forward entry is
+// accrued onto that entry's reverse counters instead of starting a new
+// flow; otherwise t becomes a new forward entry.
+func (b *BiflowTracker) Observe(t FiveTuple, c Counters) {
+	if e, ok := b.byForward[t.key()]; ok {
+		e.Forward.OctetDeltaCount += c.OctetDeltaCount
+		e.Forward.PacketDeltaCount += c.PacketDeltaCount
+		return
+	}
+	if e, ok := b.byForward[t.reverse().key()]; ok {
+		e.Reverse.OctetDeltaCount += c.OctetDeltaCount
+		e.Reverse.PacketDeltaCount += c.PacketDeltaCount
+		return
+	}
+	b.byForward[t.key()] = &biflowEntry{Forward: c}
+}
+
+// Merge finalizes t's flow, returning its forward counters together with
+// whatever was observed on its reverse direction, and forgets the entry so
+// the next flow on this 5-tuple starts fresh. t must be the forward tuple,
+// i.e. the one first seen by Observe.
+func (b *BiflowTracker) Merge(t FiveTuple) (forward, reverse Counters) {
+	k := t.key()
+	e, ok := b.byForward[k]
+	if !ok {
+		return Counters{}, Counters{}
+	}
+	delete(b.byForward, k)
+	return e.Forward, e.Reverse
+}
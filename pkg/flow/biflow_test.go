@@ -0,0 +1,88 @@
+/*
+Copyright 2022 Hiroki Shirokura.
+Copyright 2022 Keio University.
+Copyright 2022 Wide Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flow
+
+import (
+	"net"
+	"testing"
+)
+
+func TestBiflowTrackerMergesReverseTraffic(t *testing.T) {
+	tracker := NewBiflowTracker()
+	fwd := FiveTuple{
+		SourceIP:        net.ParseIP("10.0.0.1"),
+		DestinationIP:   net.ParseIP("10.0.0.2"),
+		Protocol:        6,
+		SourcePort:      1234,
+		DestinationPort: 443,
+	}
+	rev := fwd.reverse()
+
+	tracker.Observe(fwd, Counters{OctetDeltaCount: 100, PacketDeltaCount: 1})
+	tracker.Observe(rev, Counters{OctetDeltaCount: 200, PacketDeltaCount: 2})
+	tracker.Observe(fwd, Counters{OctetDeltaCount: 50, PacketDeltaCount: 1})
+
+	forward, reverse := tracker.Merge(fwd)
+	if forward.OctetDeltaCount != 150 || forward.PacketDeltaCount != 2 {
+		t.Fatalf("unexpected forward counters: %+v", forward)
+	}
+	if reverse.OctetDeltaCount != 200 || reverse.PacketDeltaCount != 2 {
+		t.Fatalf("unexpected reverse counters: %+v", reverse)
+	}
+}
+
+func TestBiflowTrackerMergeForgetsEntry(t *testing.T) {
+	tracker := NewBiflowTracker()
+	fwd := FiveTuple{
+		SourceIP:      net.ParseIP("192.168.1.1"),
+		DestinationIP: net.ParseIP("192.168.1.2"),
+		Protocol:      17,
+	}
+	tracker.Observe(fwd, Counters{OctetDeltaCount: 10, PacketDeltaCount: 1})
+	tracker.Merge(fwd)
+
+	forward, reverse := tracker.Merge(fwd)
+	if forward != (Counters{}) || reverse != (Counters{}) {
+		t.Fatalf("expected zero counters after merge, got forward=%+v reverse=%+v", forward, reverse)
+	}
+}
+
+func TestBiflowTrackerIPv4MappedKeysMatch(t *testing.T) {
+	tracker := NewBiflowTracker()
+	fwd := FiveTuple{
+		SourceIP:        net.ParseIP("10.0.0.1").To4(),
+		DestinationIP:   net.ParseIP("10.0.0.2").To4(),
+		Protocol:        6,
+		SourcePort:      1111,
+		DestinationPort: 2222,
+	}
+	fwd16 := FiveTuple{
+		SourceIP:        net.ParseIP("10.0.0.1").To16(),
+		DestinationIP:   net.ParseIP("10.0.0.2").To16(),
+		Protocol:        6,
+		SourcePort:      1111,
+		DestinationPort: 2222,
+	}
+
+	tracker.Observe(fwd, Counters{OctetDeltaCount: 5, PacketDeltaCount: 1})
+	forward, _ := tracker.Merge(fwd16)
+	if forward.OctetDeltaCount != 5 {
+		t.Fatalf("expected 4-byte and 16-byte forms of the same IP to key identically, got %+v", forward)
+	}
+}
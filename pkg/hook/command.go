@@ -0,0 +1,85 @@
+/*
+Copyright 2022 Hiroki Shirokura.
+Copyright 2022 Keio University.
+Copyright 2022 Wide Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sync"
+)
+
+// Command arguments log data using an external program like CNI. It sends
+// log data via standard input to the command it executes, and receives
+// modified log data on stdout. If the command fails, the log data is lost.
+type Command struct {
+	// Path is the executable to run.
+	Path string `yaml:"path"`
+	// Args are passed to Path as-is.
+	Args []string `yaml:"args"`
+
+	batchConfig `yaml:",inline"`
+
+	once   sync.Once
+	runner *ndjsonRunner
+}
+
+func (c *Command) Valid() bool {
+	return c.Path != "" && c.batchConfig.valid()
+}
+
+// Execute runs Path once with m on stdin, returning the JSON it writes to
+// stdout.
+func (c *Command) Execute(m map[string]interface{}) (map[string]interface{}, error) {
+	in, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(c.Path, c.Args...)
+	cmd.Stdin = bytes.NewReader(in)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("run command hook %s: %w", c.Path, err)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		return nil, fmt.Errorf("decode command hook %s output: %w", c.Path, err)
+	}
+	return result, nil
+}
+
+// ExecuteBatch streams records to Path as NDJSON on stdin and reads back
+// the same number of NDJSON records on stdout, preserving order. Requires
+// Batch to be set; when Persistent is also set, the child process is
+// reused across calls instead of being respawned per batch.
+func (c *Command) ExecuteBatch(records []map[string]interface{}) ([]map[string]interface{}, error) {
+	if !c.Batch {
+		return nil, fmt.Errorf("command hook %s: batch mode not enabled", c.Path)
+	}
+	c.once.Do(func() {
+		c.runner = newNDJSONRunner(func() *exec.Cmd {
+			return exec.Command(c.Path, c.Args...)
+		}, c.batchConfig)
+	})
+	return c.runner.Run(records)
+}
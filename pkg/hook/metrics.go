@@ -0,0 +1,61 @@
+/*
+Copyright 2022 Hiroki Shirokura.
+Copyright 2022 Keio University.
+Copyright 2022 Wide Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	batchSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "flow_exporter",
+		Subsystem: "hook",
+		Name:      "batch_size",
+		Help:      "Number of records sent to a hook child process per batch.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	})
+	batchLatencySeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "flow_exporter",
+		Subsystem: "hook",
+		Name:      "batch_latency_seconds",
+		Help:      "Time spent exchanging one batch with a hook child process.",
+		Buckets:   prometheus.DefBuckets,
+	})
+	childRestartsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "flow_exporter",
+		Subsystem: "hook",
+		Name:      "child_restarts_total",
+		Help:      "Number of times a persistent hook child process has been restarted after dying.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(batchSize, batchLatencySeconds, childRestartsTotal)
+}
+
+func recordBatch(size int, d time.Duration) {
+	batchSize.Observe(float64(size))
+	batchLatencySeconds.Observe(d.Seconds())
+}
+
+func recordChildRestart() {
+	childRestartsTotal.Inc()
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2022 Hiroki Shirokura.
+Copyright 2022 Keio University.
+Copyright 2022 Wide Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// batchConfig is the shared Batch/BatchSize/BatchTimeout/Persistent fields,
+// embedded by both Command and Shell so the NDJSON child-process machinery
+// in this file can be reused by either backend.
+type batchConfig struct {
+	// Batch switches this hook from one-process-per-record to buffering
+	// records and streaming them to the child as NDJSON on stdin, reading
+	// back the same number of NDJSON lines on stdout. Mutually exclusive
+	// with the per-record mode; when false, BatchSize/BatchTimeout/
+	// Persistent are ignored.
+	Batch bool `yaml:"batch"`
+	// BatchSize is the number of records buffered before a batch is sent
+	// to the child.
+	BatchSize int `yaml:"batchSize"`
+	// BatchTimeout bounds how long a single batch exchange with the child is
+	// allowed to take. If the child hasn't written back all records within
+	// this long, it's killed (and, in Persistent mode, restarted on the next
+	// batch) and the batch fails with a timeout error.
+	BatchTimeout time.Duration `yaml:"batchTimeout"`
+	// Persistent keeps the child process alive between batches instead of
+	// spawning a new one per batch, trading a long-running filter process
+	// for the lowest possible per-batch latency. Requires Batch.
+	Persistent bool `yaml:"persistent"`
+}
+
+func (b batchConfig) valid() bool {
+	if !b.Batch {
+		return true
+	}
+	return b.BatchSize > 0
+}
+
+// ndjsonRunner runs a command, writing records as NDJSON to its stdin and
+// reading the same number of NDJSON records back from its stdout. When
+// persistent is true, the child process is reused across calls to Run;
+// otherwise a fresh process is spawned for each batch.
+type ndjsonRunner struct {
+	newCmd func() *exec.Cmd
+	config batchConfig
+
+	mu        sync.Mutex
+	proc      *ndjsonProcess
+	restarts  int
+}
+
+func newNDJSONRunner(newCmd func() *exec.Cmd, config batchConfig) *ndjsonRunner {
+	return &ndjsonRunner{newCmd: newCmd, config: config}
+}
+
+// Run sends records to the child and returns the same number of records
+// back, preserving order. A short read (the child returning fewer lines
+// than it was given) is an error for the whole batch, since there's no way
+// to tell which records were dropped. When config.BatchTimeout is set, the
+// exchange is aborted with an error if the child hasn't answered within
+// that long, so a hung child can't stall the exporter indefinitely.
+func (r *ndjsonRunner) Run(records []map[string]interface{}) ([]map[string]interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	start := time.Now()
+	var out []map[string]interface{}
+	var err error
+	if r.config.Persistent {
+		out, err = r.runPersistent(records)
+	} else {
+		out, err = r.runOnce(records)
+	}
+	recordBatch(len(records), time.Since(start))
+	return out, err
+}
+
+// exchangeWithTimeout runs proc.exchange(records) and, if config.BatchTimeout
+// is set, kills proc and returns an error instead of waiting past it. The
+// caller is responsible for restarting proc afterwards; this never leaves
+// the exchange goroutine running past return, since it always waits for it
+// to unblock (Kill forces its pending read to fail) before returning.
+func (r *ndjsonRunner) exchangeWithTimeout(proc *ndjsonProcess, records []map[string]interface{}) ([]map[string]interface{}, error) {
+	if r.config.BatchTimeout <= 0 {
+		return proc.exchange(records)
+	}
+
+	type result struct {
+		out []map[string]interface{}
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		out, err := proc.exchange(records)
+		done <- result{out, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.out, res.err
+	case <-time.After(r.config.BatchTimeout):
+		proc.cmd.Process.Kill()
+		<-done
+		return nil, fmt.Errorf("hook child: batch timed out after %s", r.config.BatchTimeout)
+	}
+}
+
+func (r *ndjsonRunner) runOnce(records []map[string]interface{}) ([]map[string]interface{}, error) {
+	proc, err := r.start()
+	if err != nil {
+		return nil, err
+	}
+	defer proc.close()
+	return r.exchangeWithTimeout(proc, records)
+}
+
+func (r *ndjsonRunner) runPersistent(records []map[string]interface{}) ([]map[string]interface{}, error) {
+	if r.proc == nil {
+		proc, err := r.start()
+		if err != nil {
+			return nil, err
+		}
+		r.proc = proc
+	}
+	out, err := r.exchangeWithTimeout(r.proc, records)
+	if err != nil {
+		// The persistent child is assumed dead; restart it for the next
+		// batch rather than leave the hook permanently broken.
+		r.proc.close()
+		r.proc = nil
+		r.restarts++
+		recordChildRestart()
+		return nil, fmt.Errorf("persistent hook child: %w", err)
+	}
+	return out, nil
+}
+
+func (r *ndjsonRunner) start() (*ndjsonProcess, error) {
+	cmd := r.newCmd()
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start hook child: %w", err)
+	}
+	return &ndjsonProcess{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: bufio.NewReader(stdout),
+	}, nil
+}
+
+// ndjsonProcess wraps a single running child process and its NDJSON
+// framing over stdin/stdout.
+type ndjsonProcess struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+func (p *ndjsonProcess) exchange(records []map[string]interface{}) ([]map[string]interface{}, error) {
+	enc := json.NewEncoder(p.stdin)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return nil, fmt.Errorf("write ndjson record: %w", err)
+		}
+	}
+
+	out := make([]map[string]interface{}, 0, len(records))
+	for i := 0; i < len(records); i++ {
+		line, err := p.stdout.ReadBytes('\n')
+		if err != nil {
+			if err == io.EOF && len(line) == 0 {
+				return nil, fmt.Errorf("short read: got %d of %d records", i, len(records))
+			}
+			if err != io.EOF {
+				return nil, fmt.Errorf("read ndjson record: %w", err)
+			}
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(line, &m); err != nil {
+			return nil, fmt.Errorf("decode ndjson record: %w", err)
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+func (p *ndjsonProcess) close() {
+	p.stdin.Close()
+	p.cmd.Wait()
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2022 Hiroki Shirokura.
+Copyright 2022 Keio University.
+Copyright 2022 Wide Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// catRunner returns a runner whose child is "cat", which copies stdin to
+// stdout unchanged, so the NDJSON lines it receives come back byte-for-byte.
+func catRunner(cfg batchConfig) *ndjsonRunner {
+	return newNDJSONRunner(func() *exec.Cmd { return exec.Command("cat") }, cfg)
+}
+
+func TestNDJSONRunnerPreservesOrder(t *testing.T) {
+	r := catRunner(batchConfig{Batch: true, BatchSize: 3})
+	in := []map[string]interface{}{
+		{"i": float64(1)},
+		{"i": float64(2)},
+		{"i": float64(3)},
+	}
+	out, err := r.Run(in)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(out) != len(in) {
+		t.Fatalf("got %d records, want %d", len(out), len(in))
+	}
+	for i, rec := range out {
+		if rec["i"] != in[i]["i"] {
+			t.Fatalf("record %d: got %v, want %v", i, rec["i"], in[i]["i"])
+		}
+	}
+}
+
+func TestNDJSONRunnerPersistentReusesProcess(t *testing.T) {
+	r := catRunner(batchConfig{Batch: true, BatchSize: 1, Persistent: true})
+
+	if _, err := r.Run([]map[string]interface{}{{"i": float64(1)}}); err != nil {
+		t.Fatalf("first batch: %v", err)
+	}
+	first := r.proc
+	if first == nil {
+		t.Fatal("expected a persistent child process to be started")
+	}
+
+	if _, err := r.Run([]map[string]interface{}{{"i": float64(2)}}); err != nil {
+		t.Fatalf("second batch: %v", err)
+	}
+	if r.proc != first {
+		t.Fatal("expected the same child process to be reused across batches")
+	}
+}
+
+func TestNDJSONRunnerShortReadErrors(t *testing.T) {
+	// "head -n 1" only ever echoes back one line, so asking it to process
+	// two records is a short read and must error out the whole batch.
+	r := newNDJSONRunner(func() *exec.Cmd { return exec.Command("head", "-n", "1") },
+		batchConfig{Batch: true, BatchSize: 2})
+
+	_, err := r.Run([]map[string]interface{}{
+		{"i": float64(1)},
+		{"i": float64(2)},
+	})
+	if err == nil {
+		t.Fatal("expected a short-read error, got nil")
+	}
+}
+
+func TestNDJSONRunnerBatchTimeoutKillsHungChild(t *testing.T) {
+	// "sleep 5" never writes anything back, so it must be killed once
+	// BatchTimeout elapses rather than hanging Run forever.
+	r := newNDJSONRunner(func() *exec.Cmd { return exec.Command("sleep", "5") },
+		batchConfig{Batch: true, BatchSize: 1, BatchTimeout: 20 * time.Millisecond})
+
+	start := time.Now()
+	_, err := r.Run([]map[string]interface{}{{"i": float64(1)}})
+	if err == nil {
+		t.Fatal("expected a batch-timeout error, got nil")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Run took %s, expected it to return shortly after BatchTimeout", elapsed)
+	}
+}
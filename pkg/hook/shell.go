@@ -0,0 +1,84 @@
+/*
+Copyright 2022 Hiroki Shirokura.
+Copyright 2022 Keio University.
+Copyright 2022 Wide Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sync"
+)
+
+// Shell is similar to Command, but lets operators write shell scripts
+// directly in the config file, so it should be used for simple operations.
+// For example, jq can be used to add a property, resolve ifname from
+// ifindex, add hostname, and so on.
+type Shell struct {
+	// Script is passed to "/bin/sh -c".
+	Script string `yaml:"script"`
+
+	batchConfig `yaml:",inline"`
+
+	once   sync.Once
+	runner *ndjsonRunner
+}
+
+func (s *Shell) Valid() bool {
+	return s.Script != "" && s.batchConfig.valid()
+}
+
+// Execute runs Script once with m on stdin, returning the JSON it writes to
+// stdout.
+func (s *Shell) Execute(m map[string]interface{}) (map[string]interface{}, error) {
+	in, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("/bin/sh", "-c", s.Script)
+	cmd.Stdin = bytes.NewReader(in)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("run shell hook: %w", err)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		return nil, fmt.Errorf("decode shell hook output: %w", err)
+	}
+	return result, nil
+}
+
+// ExecuteBatch streams records to Script as NDJSON on stdin and reads back
+// the same number of NDJSON records on stdout, preserving order. Requires
+// Batch to be set; when Persistent is also set, the child process is
+// reused across calls instead of being respawned per batch.
+func (s *Shell) ExecuteBatch(records []map[string]interface{}) ([]map[string]interface{}, error) {
+	if !s.Batch {
+		return nil, fmt.Errorf("shell hook: batch mode not enabled")
+	}
+	s.once.Do(func() {
+		s.runner = newNDJSONRunner(func() *exec.Cmd {
+			return exec.Command("/bin/sh", "-c", s.Script)
+		}, s.batchConfig)
+	})
+	return s.runner.Run(records)
+}
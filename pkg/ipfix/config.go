@@ -20,6 +20,8 @@ package ipfix
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/wide-vsix/linux-flow-exporter/pkg/hook"
 )
@@ -27,6 +29,30 @@ import (
 type OutputCollector struct {
 	RemoteAddress string `yaml:"remoteAddress"`
 	LocalAddress  string `yaml:"localAddress"`
+	// Transport selects the wire transport: "udp" (default), "tcp", or
+	// "tls". TCP and TLS use a single reconnecting message stream instead
+	// of UDP's MTU-fragmentation model; see pkg/ipfix/transport.go.
+	Transport string `yaml:"transport"`
+	// TLS configures the "tls" transport. Ignored otherwise.
+	TLS *OutputCollectorTLS `yaml:"tls"`
+}
+
+// OutputCollectorTLS configures the "tls" OutputCollector.Transport.
+type OutputCollectorTLS struct {
+	CA                 string `yaml:"ca"`
+	Cert               string `yaml:"cert"`
+	Key                string `yaml:"key"`
+	ServerName         string `yaml:"serverName"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+}
+
+// transport returns c.Transport, defaulting to "udp" for backward
+// compatibility with configs written before TCP/TLS support existed.
+func (c OutputCollector) transport() string {
+	if c.Transport == "" {
+		return "udp"
+	}
+	return c.Transport
 }
 
 // Hook can speficy external mechianism to make log-data updated Only one of the
@@ -74,9 +100,15 @@ func (h Hook) Valid() bool {
 	cnt := 0
 	if h.Command != nil {
 		cnt++
+		if !h.Command.Valid() {
+			return false
+		}
 	}
 	if h.Shell != nil {
 		cnt++
+		if !h.Shell.Valid() {
+			return false
+		}
 	}
 	return cnt == 1
 }
@@ -94,6 +126,68 @@ func (h Hook) Execute(m map[string]interface{}) (map[string]interface{}, error)
 	return nil, fmt.Errorf("(no reach code)")
 }
 
+// Batch reports whether this hook was configured for batch mode (see
+// hook.Command.Batch / hook.Shell.Batch), in which case callers should
+// gather records and call ExecuteBatch instead of Execute per record.
+func (h Hook) Batch() bool {
+	switch {
+	case h.Command != nil:
+		return h.Command.Batch
+	case h.Shell != nil:
+		return h.Shell.Batch
+	default:
+		return false
+	}
+}
+
+// ExecuteBatch is the batch-mode counterpart of Execute: it streams all of
+// records to the hook's child process as NDJSON and returns the same
+// number of records back, in order.
+func (h Hook) ExecuteBatch(records []map[string]interface{}) ([]map[string]interface{}, error) {
+	if !h.Valid() {
+		return nil, fmt.Errorf("invalid hook")
+	}
+	if h.Shell != nil {
+		return h.Shell.ExecuteBatch(records)
+	}
+	if h.Command != nil {
+		return h.Command.ExecuteBatch(records)
+	}
+	return nil, fmt.Errorf("(no reach code)")
+}
+
+// BatchSize returns the configured hook.Command.BatchSize /
+// hook.Shell.BatchSize, or 0 if Batch isn't enabled.
+func (h Hook) BatchSize() int {
+	if !h.Batch() {
+		return 0
+	}
+	switch {
+	case h.Command != nil:
+		return h.Command.BatchSize
+	case h.Shell != nil:
+		return h.Shell.BatchSize
+	default:
+		return 0
+	}
+}
+
+// BatchTimeout returns the configured hook.Command.BatchTimeout /
+// hook.Shell.BatchTimeout, or 0 if Batch isn't enabled.
+func (h Hook) BatchTimeout() time.Duration {
+	if !h.Batch() {
+		return 0
+	}
+	switch {
+	case h.Command != nil:
+		return h.Command.BatchTimeout
+	case h.Shell != nil:
+		return h.Shell.BatchTimeout
+	default:
+		return 0
+	}
+}
+
 type OutputLog struct {
 	File string `yaml:"file"`
 	// Hooks are the extention for special argmentation. Multiple Hooks can be
@@ -103,13 +197,133 @@ type OutputLog struct {
 	Hooks []Hook `yaml:"hooks"`
 }
 
+// Process runs decorators before Hooks, then this OutputLog's Hooks chain, in
+// order. Unlike Hooks, decorators are built-in (see Decoration) and run
+// in-process, so they don't pay the fork/exec cost Hook.Execute does.
+func (o OutputLog) Process(m map[string]interface{},
+	decorators []Decorator) (map[string]interface{}, error) {
+
+	for _, d := range decorators {
+		if err := d.Decorate(m); err != nil {
+			return nil, fmt.Errorf("decorate: %w", err)
+		}
+	}
+	for _, h := range o.Hooks {
+		var err error
+		m, err = h.Execute(m)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// ProcessBatch is the batch-mode counterpart of Process: it decorates every
+// record, then runs it through Hooks, grouping records per hook so batch
+// hooks (see Hook.Batch) are called once per Hook.BatchSize()-sized chunk
+// instead of once per record or once for the whole slice. Non-batch hooks
+// still fall back to Hook.Execute in a loop, so batch and non-batch hooks
+// can be mixed in the same chain.
+func (o OutputLog) ProcessBatch(records []map[string]interface{},
+	decorators []Decorator) ([]map[string]interface{}, error) {
+
+	for _, m := range records {
+		for _, d := range decorators {
+			if err := d.Decorate(m); err != nil {
+				return nil, fmt.Errorf("decorate: %w", err)
+			}
+		}
+	}
+
+	for _, h := range o.Hooks {
+		if h.Batch() {
+			out := make([]map[string]interface{}, 0, len(records))
+			for _, chunk := range chunkRecords(records, h.BatchSize()) {
+				processed, err := h.ExecuteBatch(chunk)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, processed...)
+			}
+			records = out
+			continue
+		}
+		for i, m := range records {
+			var err error
+			records[i], err = h.Execute(m)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return records, nil
+}
+
+// chunkRecords splits records into groups of at most size, preserving
+// order. A non-positive size returns records as a single chunk.
+func chunkRecords(records []map[string]interface{}, size int) [][]map[string]interface{} {
+	if size <= 0 || len(records) <= size {
+		return [][]map[string]interface{}{records}
+	}
+	chunks := make([][]map[string]interface{}, 0, (len(records)+size-1)/size)
+	for len(records) > 0 {
+		n := size
+		if n > len(records) {
+			n = len(records)
+		}
+		chunks = append(chunks, records[:n])
+		records = records[n:]
+	}
+	return chunks
+}
+
+// OutputGRPC ships Flow records to a collector that speaks protobuf over
+// gRPC (see pkg/pbflow), as an alternative to raw IPFIX/UDP for operators
+// who need it to survive MTU issues, use mTLS, or integrate with an
+// existing Kubernetes flow-collector deployment.
+type OutputGRPC struct {
+	// Endpoint is the collector's "host:port" gRPC address.
+	Endpoint string `yaml:"endpoint"`
+	// TLS enables transport security on the connection. Nil means plaintext.
+	TLS *OutputGRPCTLS `yaml:"tls"`
+	// BatchSize is the number of Flows sent per FlowRecords RPC.
+	BatchSize int `yaml:"batchSize"`
+	// QueueLength bounds how many Flows may be buffered waiting to be
+	// batched and sent before Backpressure kicks in.
+	QueueLength int `yaml:"queueLength"`
+	// Backpressure selects what happens once QueueLength is reached:
+	// "dropOldest" discards the oldest queued Flow to make room for the new
+	// one, "block" makes the caller wait for room. Defaults to "dropOldest".
+	Backpressure string `yaml:"backpressure"`
+}
+
+// OutputGRPCTLS configures mTLS for an OutputGRPC endpoint.
+type OutputGRPCTLS struct {
+	CA                 string `yaml:"ca"`
+	Cert               string `yaml:"cert"`
+	Key                string `yaml:"key"`
+	ServerName         string `yaml:"serverName"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+}
+
 type Output struct {
 	Collector *OutputCollector `yaml:"collector"`
 	Log       *OutputLog       `yaml:"log"`
+	GRPC      *OutputGRPC      `yaml:"grpc"`
 }
 
 func (o Output) Valid() bool {
-	return !(o.Collector != nil && o.Log != nil)
+	cnt := 0
+	if o.Collector != nil {
+		cnt++
+	}
+	if o.Log != nil {
+		cnt++
+	}
+	if o.GRPC != nil {
+		cnt++
+	}
+	return cnt == 1
 }
 
 type Config struct {
@@ -130,15 +344,32 @@ type Config struct {
 	TimerForceDrainSeconds uint `yaml:"timerForceDrainSeconds"`
 	// Output can contain multiple destinations to which the recorded flow cache
 	// is transferred. IPFIX Collector, Filelog, etc. can be specified.
-	Outputs   []Output `yaml:"outputs"`
-	Templates []struct {
-		ID       uint16 `yaml:"id"`
+	Outputs []Output `yaml:"outputs"`
+	// Decoration configures the built-in fields (agentIP, hostname,
+	// ingress/egressInterfaceName) added to every record before Hooks run.
+	// It is shared by all output instances, same as MaxIpfixMessageLen.
+	Decoration *Decoration `yaml:"decoration"`
+	Templates  []struct {
+		ID uint16 `yaml:"id"`
+		// Biflow enables RFC 5103: every IE listed in Template is emitted
+		// twice, once as the forward IE and once as its reverse-PEN
+		// counterpart, so a single flow record carries both forward and
+		// reverse counters (e.g. byte/packet counts, TCP flags).
+		Biflow   bool `yaml:"biflow"`
 		Template []struct {
 			Name string `yaml:"name"`
 		} `yaml:"template"`
 	} `yaml:"templates"`
 }
 
+// reversePEN is the IANA-assigned enterprise number reserved for reverse
+// Information Elements (RFC 5103 section 6.1).
+const reversePEN = 29305
+
+// reverseInformationElementBit marks an IE as enterprise-specific in the
+// IPFIX template field type, per RFC 5101 section 3.2.
+const reverseInformationElementBit = 0x8000
+
 type FlowFile struct {
 	FlowSets []struct {
 		TemplateID uint16 `yaml:"templateId"`
@@ -201,7 +432,34 @@ type fieldTableItem struct {
 	Length int
 }
 
+// Validate checks invariants across the config that the yaml schema itself
+// can't express, e.g. that reverse Information Elements (RFC 5103) are only
+// referenced from a biflow template, where ToFlowTemplatesMessage appends
+// the reverse-PEN counterpart of every listed IE automatically.
+func (c Config) Validate() error {
+	for _, item := range c.Templates {
+		if item.Biflow {
+			continue
+		}
+		for _, t := range item.Template {
+			if isReverseIEName(t.Name) {
+				return fmt.Errorf("template %d: reverse IE %q used outside a biflow template",
+					item.ID, t.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func isReverseIEName(name string) bool {
+	return strings.HasPrefix(name, "reverse")
+}
+
 func (c Config) ToFlowTemplatesMessage() (TemplateMessage, error) {
+	if err := c.Validate(); err != nil {
+		return TemplateMessage{}, err
+	}
+
 	msg := TemplateMessage{
 		Header: Header{
 			VersionNumber:  10,
@@ -227,6 +485,35 @@ func (c Config) ToFlowTemplatesMessage() (TemplateMessage, error) {
 				FieldType:   uint16(value),
 				FieldLength: uint16(length),
 			})
+
+			// RFC 5103 biflow: every forward IE is immediately followed by
+			// its reverse-PEN counterpart, so a single record carries both
+			// forward and reverse counters.
+			if item.Biflow {
+				fields = append(fields, FlowTemplateField{
+					FieldType:        uint16(value) | reverseInformationElementBit,
+					FieldLength:      uint16(length),
+					EnterpriseNumber: reversePEN,
+				})
+			}
+		}
+
+		// When AgentIP decoration is enabled, every collector template
+		// automatically carries the exporter's address as an IPFIX IE,
+		// instead of operators having to list it by hand in each template.
+		if name, ok := c.Decoration.AgentIPTemplateField(); ok {
+			value, err := getIPFixFieldsValueByName(name)
+			if err != nil {
+				return msg, err
+			}
+			length, err := getIPFixFieldsLengthByName(name)
+			if err != nil {
+				return msg, err
+			}
+			fields = append(fields, FlowTemplateField{
+				FieldType:   uint16(value),
+				FieldLength: uint16(length),
+			})
 		}
 
 		msg.Templates = append(msg.Templates, FlowTemplate{
@@ -272,6 +559,14 @@ func getTemplateFieldTypes(id uint16, config *Config) ([]uint16, error) {
 	return nil, fmt.Errorf("not found")
 }
 
+// getTemplateLength returns the per-flow record length for template id, in
+// the same terms ToFlowTemplatesMessage uses to build that template's field
+// list: biflow templates carry each IE twice (forward and reverse), and the
+// AgentIP decoration IE is appended to every template when enabled. Keeping
+// this in sync with ToFlowTemplatesMessage matters because
+// ToFlowDataMessages uses it to compute how many flows fit in
+// MaxIpfixMessageLen; an undersized length here means emitted messages
+// silently exceed that cap.
 func (c Config) getTemplateLength(id uint16) (int, error) {
 	for _, template := range c.Templates {
 		if template.ID == id {
@@ -282,6 +577,16 @@ func (c Config) getTemplateLength(id uint16) (int, error) {
 					return 0, err
 				}
 				len += tmpLen
+				if template.Biflow {
+					len += tmpLen
+				}
+			}
+			if name, ok := c.Decoration.AgentIPTemplateField(); ok {
+				tmpLen, err := getIPFixFieldsLengthByName(name)
+				if err != nil {
+					return 0, err
+				}
+				len += tmpLen
 			}
 			return len, nil
 		}
@@ -0,0 +1,139 @@
+/*
+Copyright 2022 Hiroki Shirokura.
+Copyright 2022 Keio University.
+Copyright 2022 Wide Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipfix
+
+import "testing"
+
+// TestGetTemplateLengthMatchesToFlowTemplatesMessage guards against
+// getTemplateLength drifting out of sync with ToFlowTemplatesMessage: both
+// must agree on how many bytes a biflow template with AgentIP decoration
+// puts on the wire, since ToFlowDataMessages' MTU fragmentation math relies
+// on getTemplateLength alone.
+func TestGetTemplateLengthMatchesToFlowTemplatesMessage(t *testing.T) {
+	config := Config{
+		Decoration: &Decoration{AgentIP: "192.0.2.1"},
+		Templates: []struct {
+			ID       uint16 `yaml:"id"`
+			Biflow   bool   `yaml:"biflow"`
+			Template []struct {
+				Name string `yaml:"name"`
+			} `yaml:"template"`
+		}{
+			templateOf(256, true, "octetDeltaCount", "packetDeltaCount"),
+		},
+	}
+
+	got, err := config.getTemplateLength(256)
+	if err != nil {
+		t.Fatalf("getTemplateLength: %v", err)
+	}
+
+	msg, err := config.ToFlowTemplatesMessage()
+	if err != nil {
+		t.Fatalf("ToFlowTemplatesMessage: %v", err)
+	}
+	want := 0
+	for _, tmpl := range msg.Templates {
+		if tmpl.TemplateID != 256 {
+			continue
+		}
+		for _, field := range tmpl.Fields {
+			want += int(field.FieldLength)
+		}
+	}
+
+	if got != want {
+		t.Fatalf("getTemplateLength = %d, but ToFlowTemplatesMessage emits %d bytes of fields", got, want)
+	}
+}
+
+// templateOf is a shorthand for building Config.Templates entries, whose
+// type is an anonymous struct.
+func templateOf(id uint16, biflow bool, fieldNames ...string) struct {
+	ID       uint16 `yaml:"id"`
+	Biflow   bool   `yaml:"biflow"`
+	Template []struct {
+		Name string `yaml:"name"`
+	} `yaml:"template"`
+} {
+	fields := make([]struct {
+		Name string `yaml:"name"`
+	}, len(fieldNames))
+	for i, name := range fieldNames {
+		fields[i].Name = name
+	}
+	return struct {
+		ID       uint16 `yaml:"id"`
+		Biflow   bool   `yaml:"biflow"`
+		Template []struct {
+			Name string `yaml:"name"`
+		} `yaml:"template"`
+	}{ID: id, Biflow: biflow, Template: fields}
+}
+
+func TestConfigValidateRejectsReverseIEOutsideBiflowTemplate(t *testing.T) {
+	config := Config{
+		Templates: []struct {
+			ID       uint16 `yaml:"id"`
+			Biflow   bool   `yaml:"biflow"`
+			Template []struct {
+				Name string `yaml:"name"`
+			} `yaml:"template"`
+		}{
+			templateOf(256, false, "octetDeltaCount", "reverseOctetDeltaCount"),
+		},
+	}
+	if err := config.Validate(); err == nil {
+		t.Fatal("expected an error for a reverse IE used outside a biflow template")
+	}
+}
+
+func TestConfigValidateAllowsReverseIENameWithinBiflowTemplate(t *testing.T) {
+	config := Config{
+		Templates: []struct {
+			ID       uint16 `yaml:"id"`
+			Biflow   bool   `yaml:"biflow"`
+			Template []struct {
+				Name string `yaml:"name"`
+			} `yaml:"template"`
+		}{
+			templateOf(256, true, "octetDeltaCount", "reverseOctetDeltaCount"),
+		},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+}
+
+func TestToFlowTemplatesMessageRejectsInvalidConfig(t *testing.T) {
+	config := Config{
+		Templates: []struct {
+			ID       uint16 `yaml:"id"`
+			Biflow   bool   `yaml:"biflow"`
+			Template []struct {
+				Name string `yaml:"name"`
+			} `yaml:"template"`
+		}{
+			templateOf(256, false, "reverseOctetDeltaCount"),
+		},
+	}
+	if _, err := config.ToFlowTemplatesMessage(); err == nil {
+		t.Fatal("expected ToFlowTemplatesMessage to reject a config Validate() rejects")
+	}
+}
@@ -0,0 +1,345 @@
+/*
+Copyright 2022 Hiroki Shirokura.
+Copyright 2022 Keio University.
+Copyright 2022 Wide Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipfix
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/vishvananda/netlink"
+)
+
+// defaultIfNameRefreshInterval is used when Decoration.IfNameRefreshInterval
+// is left at zero.
+const defaultIfNameRefreshInterval = 30 * time.Second
+
+// Decorator argments log data in-process, without the fork/exec cost of a
+// Hook. Decorators run before Hook.Execute so that hooks written by
+// operators (jq scripts, external commands) can already see fields like
+// hostname or agentIP instead of having to resolve them on every record.
+type Decorator interface {
+	// Decorate mutates m in place, adding or overwriting fields.
+	Decorate(m map[string]interface{}) error
+}
+
+// Decoration configures the built-in Decorators. Unlike Hook, all enabled
+// decorators run, in the order AgentIP, Hostname, IfName, since they argment
+// independent fields rather than transforming the whole record.
+type Decoration struct {
+	// AgentIP controls how the exporter's own address is resolved and
+	// attached to every record as "agentIP".
+	//
+	// - "auto": open a UDP socket toward the collector's RemoteAddress and
+	//   read the local address the kernel picked for that route.
+	// - an interface name (e.g. "eth0"): use the first global-scope
+	//   address on that interface. Any non-empty value that is neither
+	//   "auto" nor an IP address is taken as an interface name.
+	// - a literal IP address: used as-is.
+	//
+	// Empty disables AgentIP decoration.
+	AgentIP string `yaml:"agentIP"`
+	// Hostname adds the exporter's os.Hostname() as "hostname" on every
+	// record.
+	Hostname bool `yaml:"hostname"`
+	// IfName resolves ingressInterface/egressInterface ifindexes to their
+	// interface name via netlink, adding "ingressInterfaceName" and
+	// "egressInterfaceName".
+	IfName bool `yaml:"ifName"`
+	// IfNameRefreshInterval controls how often the ifindex->name cache used
+	// by IfName is re-read from netlink. Defaults to
+	// defaultIfNameRefreshInterval when zero. Only meaningful when IfName is
+	// set.
+	IfNameRefreshInterval time.Duration `yaml:"ifNameRefreshInterval"`
+
+	// agentIPIsV6 is cached by NewDecorators once AgentIP has been resolved,
+	// so ToFlowTemplatesMessage knows whether to add exporterIPv4Address or
+	// exporterIPv6Address to the IPFIX templates without re-resolving it.
+	agentIPIsV6 bool
+}
+
+// AgentIPTemplateField returns the IPFIX IE name ("exporterIPv4Address" or
+// "exporterIPv6Address") that should be added to every collector template
+// when AgentIP decoration is enabled, and whether it applies at all.
+func (d *Decoration) AgentIPTemplateField() (string, bool) {
+	if d == nil || d.AgentIP == "" {
+		return "", false
+	}
+	if d.agentIPIsV6 {
+		return "exporterIPv6Address", true
+	}
+	return "exporterIPv4Address", true
+}
+
+// NewDecorators builds the Decorator chain described by d. remoteAddress is
+// the OutputCollector.RemoteAddress used by the AgentIP "auto" mode to pick
+// the outbound interface; it may be empty if no collector output is
+// configured, in which case "auto" falls back to the first non-loopback
+// global-scope address on the host.
+func NewDecorators(d *Decoration, remoteAddress string) ([]Decorator, error) {
+	if d == nil {
+		return nil, nil
+	}
+	decorators := []Decorator{}
+	if d.AgentIP != "" {
+		a, err := newAgentIPDecorator(d.AgentIP, remoteAddress)
+		if err != nil {
+			return nil, fmt.Errorf("agentIP decorator: %w", err)
+		}
+		d.agentIPIsV6 = a.ip.To4() == nil
+		decorators = append(decorators, a)
+	}
+	if d.Hostname {
+		h, err := newHostnameDecorator()
+		if err != nil {
+			return nil, fmt.Errorf("hostname decorator: %w", err)
+		}
+		decorators = append(decorators, h)
+	}
+	if d.IfName {
+		interval := d.IfNameRefreshInterval
+		if interval <= 0 {
+			interval = defaultIfNameRefreshInterval
+		}
+		i, err := newIfNameDecorator(interval)
+		if err != nil {
+			return nil, fmt.Errorf("ifName decorator: %w", err)
+		}
+		decorators = append(decorators, i)
+	}
+	return decorators, nil
+}
+
+// AgentIP attaches the exporter's outbound address, resolved once at
+// startup, to every record as "agentIP".
+type AgentIP struct {
+	ip net.IP
+}
+
+func newAgentIPDecorator(mode, remoteAddress string) (*AgentIP, error) {
+	ip, err := resolveAgentIP(mode, remoteAddress)
+	if err != nil {
+		return nil, err
+	}
+	return &AgentIP{ip: ip}, nil
+}
+
+func (a *AgentIP) Decorate(m map[string]interface{}) error {
+	m["agentIP"] = a.ip.String()
+	return nil
+}
+
+// resolveAgentIP implements the three AgentIP modes documented on
+// Decoration.AgentIP.
+func resolveAgentIP(mode, remoteAddress string) (net.IP, error) {
+	if ip := net.ParseIP(mode); ip != nil {
+		return ip, nil
+	}
+	if mode == "auto" {
+		return autodetectAgentIP(remoteAddress)
+	}
+	return agentIPFromInterface(mode)
+}
+
+// autodetectAgentIP opens a UDP socket toward remoteAddress and reads back
+// the local address the kernel chose for that route. No packet is actually
+// sent; dialing UDP only resolves routing. If remoteAddress is empty, the
+// first non-loopback global-scope address on the host is used instead.
+func autodetectAgentIP(remoteAddress string) (net.IP, error) {
+	if remoteAddress == "" {
+		return firstGlobalUnicastIP()
+	}
+	conn, err := net.Dial("udp", remoteAddress)
+	if err != nil {
+		return firstGlobalUnicastIP()
+	}
+	defer conn.Close()
+	addr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return nil, fmt.Errorf("unexpected local address type %T", conn.LocalAddr())
+	}
+	return addr.IP, nil
+}
+
+func firstGlobalUnicastIP() (net.IP, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 || iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipnet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			if ipnet.IP.IsGlobalUnicast() {
+				return ipnet.IP, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no non-loopback global-scope address found")
+}
+
+func agentIPFromInterface(name string) (net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if ipnet.IP.IsGlobalUnicast() {
+			return ipnet.IP, nil
+		}
+	}
+	return nil, fmt.Errorf("interface %s has no global-scope address", name)
+}
+
+// Hostname attaches os.Hostname(), cached at startup, to every record as
+// "hostname".
+type Hostname struct {
+	name string
+}
+
+func newHostnameDecorator() (*Hostname, error) {
+	name, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	return &Hostname{name: name}, nil
+}
+
+func (h *Hostname) Decorate(m map[string]interface{}) error {
+	m["hostname"] = h.name
+	return nil
+}
+
+// IfName resolves the ingressInterface/egressInterface ifindexes IPFIX
+// already exports to human-readable names, maintaining a netlink-refreshed
+// ifindex->name cache so the resolution doesn't need a syscall per record.
+// The cache is populated once by newIfNameDecorator and kept fresh by a
+// background goroutine that calls Refresh every interval, following the
+// same self-managed-lifecycle pattern as StreamTransport; call Close to
+// stop it.
+type IfName struct {
+	mu      sync.RWMutex
+	byIndex map[int]string
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+// newIfNameDecorator does a synchronous, blocking Refresh before returning,
+// so the cache is already populated for the first record decorated, then
+// starts a background goroutine refreshing it every interval.
+func newIfNameDecorator(interval time.Duration) (*IfName, error) {
+	i := &IfName{byIndex: map[int]string{}, stopCh: make(chan struct{})}
+	if err := i.Refresh(); err != nil {
+		return nil, err
+	}
+	go i.run(interval)
+	return i, nil
+}
+
+func (i *IfName) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-i.stopCh:
+			return
+		case <-ticker.C:
+			// Best-effort: a transient netlink error just means the cache
+			// goes one interval longer before picking up interface
+			// changes, so it isn't surfaced anywhere.
+			_ = i.Refresh()
+		}
+	}
+}
+
+// Close stops the background refresh goroutine.
+func (i *IfName) Close() {
+	i.stopOnce.Do(func() { close(i.stopCh) })
+}
+
+// Refresh re-reads the ifindex->name mapping from netlink.
+func (i *IfName) Refresh() error {
+	links, err := netlink.LinkList()
+	if err != nil {
+		return err
+	}
+	byIndex := make(map[int]string, len(links))
+	for _, link := range links {
+		attrs := link.Attrs()
+		byIndex[attrs.Index] = attrs.Name
+	}
+	i.mu.Lock()
+	i.byIndex = byIndex
+	i.mu.Unlock()
+	return nil
+}
+
+func (i *IfName) Decorate(m map[string]interface{}) error {
+	if v, ok := m["ingressInterface"]; ok {
+		if name, ok := i.lookup(v); ok {
+			m["ingressInterfaceName"] = name
+		}
+	}
+	if v, ok := m["egressInterface"]; ok {
+		if name, ok := i.lookup(v); ok {
+			m["egressInterfaceName"] = name
+		}
+	}
+	return nil
+}
+
+func (i *IfName) lookup(v interface{}) (string, bool) {
+	var idx int
+	switch t := v.(type) {
+	case int:
+		idx = t
+	case int32:
+		idx = int(t)
+	case uint32:
+		idx = int(t)
+	case float64:
+		idx = int(t)
+	default:
+		return "", false
+	}
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	name, ok := i.byIndex[idx]
+	return name, ok
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2022 Hiroki Shirokura.
+Copyright 2022 Keio University.
+Copyright 2022 Wide Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipfix
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestResolveAgentIPLiteral(t *testing.T) {
+	ip, err := resolveAgentIP("203.0.113.5", "")
+	if err != nil {
+		t.Fatalf("resolveAgentIP: %v", err)
+	}
+	if ip.String() != "203.0.113.5" {
+		t.Fatalf("got %s, want 203.0.113.5", ip)
+	}
+}
+
+func TestResolveAgentIPAutoFallsBackWithoutRemoteAddress(t *testing.T) {
+	ip, err := resolveAgentIP("auto", "")
+	if err != nil {
+		t.Fatalf("resolveAgentIP: %v", err)
+	}
+	if ip == nil || ip.IsLoopback() {
+		t.Fatalf("expected a non-loopback address, got %v", ip)
+	}
+}
+
+func TestResolveAgentIPUnknownInterfaceErrors(t *testing.T) {
+	if _, err := resolveAgentIP("does-not-exist0", ""); err == nil {
+		t.Fatal("expected an error for a nonexistent interface")
+	}
+}
+
+func TestFirstGlobalUnicastIPSkipsLoopback(t *testing.T) {
+	ip, err := firstGlobalUnicastIP()
+	if err != nil {
+		t.Fatalf("firstGlobalUnicastIP: %v", err)
+	}
+	if ip.IsLoopback() {
+		t.Fatalf("got a loopback address: %s", ip)
+	}
+}
+
+func TestAgentIPFromInterfaceLoopbackHasNoGlobalAddress(t *testing.T) {
+	if _, err := agentIPFromInterface("lo"); err == nil {
+		t.Fatal("expected an error: lo has no global-scope address")
+	}
+}
+
+func TestAgentIPDecoratorAddsField(t *testing.T) {
+	a := &AgentIP{ip: net.ParseIP("198.51.100.7")}
+	m := map[string]interface{}{}
+	if err := a.Decorate(m); err != nil {
+		t.Fatalf("Decorate: %v", err)
+	}
+	if m["agentIP"] != "198.51.100.7" {
+		t.Fatalf("got %v, want 198.51.100.7", m["agentIP"])
+	}
+}
+
+func TestIfNameDecoratorPopulatesCacheOnConstruction(t *testing.T) {
+	i, err := newIfNameDecorator(time.Hour)
+	if err != nil {
+		t.Fatalf("newIfNameDecorator: %v", err)
+	}
+	defer i.Close()
+
+	// lo is always interface index 1 on Linux, and newIfNameDecorator must
+	// have already populated the cache synchronously: this is the bug the
+	// review caught, where the cache stayed empty until some caller invoked
+	// Refresh, which nothing ever did.
+	m := map[string]interface{}{"ingressInterface": 1}
+	if err := i.Decorate(m); err != nil {
+		t.Fatalf("Decorate: %v", err)
+	}
+	if m["ingressInterfaceName"] != "lo" {
+		t.Fatalf("got ingressInterfaceName=%v, want \"lo\"", m["ingressInterfaceName"])
+	}
+}
+
+func TestIfNameLookupAcceptsNumericTypes(t *testing.T) {
+	i, err := newIfNameDecorator(time.Hour)
+	if err != nil {
+		t.Fatalf("newIfNameDecorator: %v", err)
+	}
+	defer i.Close()
+
+	for _, v := range []interface{}{1, int32(1), uint32(1), float64(1)} {
+		name, ok := i.lookup(v)
+		if !ok || name != "lo" {
+			t.Fatalf("lookup(%v) = (%q, %v), want (\"lo\", true)", v, name, ok)
+		}
+	}
+	if _, ok := i.lookup("1"); ok {
+		t.Fatal("lookup of an unsupported type should report not-found")
+	}
+}
+
+func TestIfNameDecorateIgnoresUnknownIndex(t *testing.T) {
+	i, err := newIfNameDecorator(time.Hour)
+	if err != nil {
+		t.Fatalf("newIfNameDecorator: %v", err)
+	}
+	defer i.Close()
+
+	m := map[string]interface{}{"egressInterface": 1 << 20}
+	if err := i.Decorate(m); err != nil {
+		t.Fatalf("Decorate: %v", err)
+	}
+	if _, ok := m["egressInterfaceName"]; ok {
+		t.Fatalf("expected no egressInterfaceName for an unknown ifindex, got %v", m["egressInterfaceName"])
+	}
+}
+
+func TestIfNameRefreshRunsInBackground(t *testing.T) {
+	i, err := newIfNameDecorator(10 * time.Millisecond)
+	if err != nil {
+		t.Fatalf("newIfNameDecorator: %v", err)
+	}
+	defer i.Close()
+
+	i.mu.Lock()
+	i.byIndex = map[int]string{}
+	i.mu.Unlock()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if _, ok := i.lookup(1); ok {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("background refresh never repopulated the cache")
+}
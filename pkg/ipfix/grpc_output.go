@@ -0,0 +1,159 @@
+/*
+Copyright 2022 Hiroki Shirokura.
+Copyright 2022 Keio University.
+Copyright 2022 Wide Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipfix
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/wide-vsix/linux-flow-exporter/pkg/pbflow"
+)
+
+// GRPCClient batches Flows and ships them to an OutputGRPC endpoint. Flows
+// are queued by Send, which flushes synchronously, in the caller's
+// goroutine, once BatchSize Flows have accumulated.
+type GRPCClient struct {
+	config OutputGRPC
+	client pbflow.FlowCollectorClient
+	conn   *grpc.ClientConn
+
+	mu    sync.Mutex
+	queue []*pbflow.Flow
+}
+
+// NewGRPCClient dials cfg.Endpoint and returns a client ready to accept
+// Flows via Send. cfg.BatchSize and cfg.QueueLength default to 100 and
+// 10*BatchSize respectively when left at zero.
+func NewGRPCClient(cfg OutputGRPC) (*GRPCClient, error) {
+	creds := insecure.NewCredentials()
+	if cfg.TLS != nil {
+		tlsConfig, err := buildTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("grpc tls config: %w", err)
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	conn, err := grpc.Dial(cfg.Endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("grpc dial %s: %w", cfg.Endpoint, err)
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.QueueLength <= 0 {
+		cfg.QueueLength = 10 * cfg.BatchSize
+	}
+	if cfg.Backpressure == "" {
+		cfg.Backpressure = "dropOldest"
+	}
+	return &GRPCClient{
+		config: cfg,
+		client: pbflow.NewFlowCollectorClient(conn),
+		conn:   conn,
+	}, nil
+}
+
+func buildTLSConfig(cfg *OutputGRPCTLS) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	if cfg.CA != "" {
+		pem, err := os.ReadFile(cfg.CA)
+		if err != nil {
+			return nil, fmt.Errorf("read ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CA)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.Cert != "" && cfg.Key != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.Cert, cfg.Key)
+		if err != nil {
+			return nil, fmt.Errorf("load client cert: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// Send enqueues flow, flushing a batch to the collector once BatchSize
+// Flows are queued. When the queue is at QueueLength and Backpressure is
+// "dropOldest", the oldest queued Flow is discarded to make room; when
+// Backpressure is "block", Send flushes synchronously instead of queuing
+// past QueueLength.
+func (c *GRPCClient) Send(ctx context.Context, flow *pbflow.Flow) error {
+	c.mu.Lock()
+	if len(c.queue) >= c.config.QueueLength {
+		if c.config.Backpressure == "block" {
+			batch := c.queue
+			c.queue = nil
+			c.mu.Unlock()
+			if err := c.flush(ctx, batch); err != nil {
+				return err
+			}
+			c.mu.Lock()
+		} else {
+			c.queue = c.queue[1:]
+		}
+	}
+	c.queue = append(c.queue, flow)
+	var batch []*pbflow.Flow
+	if len(c.queue) >= c.config.BatchSize {
+		batch = c.queue
+		c.queue = nil
+	}
+	c.mu.Unlock()
+
+	if batch != nil {
+		return c.flush(ctx, batch)
+	}
+	return nil
+}
+
+// Flush sends any queued Flows immediately, regardless of BatchSize.
+func (c *GRPCClient) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	batch := c.queue
+	c.queue = nil
+	c.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+	return c.flush(ctx, batch)
+}
+
+func (c *GRPCClient) flush(ctx context.Context, batch []*pbflow.Flow) error {
+	_, err := c.client.FlowRecords(ctx, &pbflow.FlowRecordsRequest{Flows: batch})
+	return err
+}
+
+func (c *GRPCClient) Close() error {
+	return c.conn.Close()
+}
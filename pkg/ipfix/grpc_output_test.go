@@ -0,0 +1,250 @@
+/*
+Copyright 2022 Hiroki Shirokura.
+Copyright 2022 Keio University.
+Copyright 2022 Wide Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipfix
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/wide-vsix/linux-flow-exporter/pkg/pbflow"
+)
+
+// fakeFlowCollector is an in-process FlowCollectorServer that records every
+// FlowRecords call it receives, for GRPCClient tests to assert against.
+type fakeFlowCollector struct {
+	pbflow.UnimplementedFlowCollectorServer
+
+	mu      sync.Mutex
+	batches [][]*pbflow.Flow
+}
+
+func (f *fakeFlowCollector) FlowRecords(ctx context.Context, req *pbflow.FlowRecordsRequest) (*pbflow.FlowRecordsResponse, error) {
+	f.mu.Lock()
+	f.batches = append(f.batches, req.Flows)
+	f.mu.Unlock()
+	return &pbflow.FlowRecordsResponse{}, nil
+}
+
+func (f *fakeFlowCollector) received() [][]*pbflow.Flow {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([][]*pbflow.Flow, len(f.batches))
+	copy(out, f.batches)
+	return out
+}
+
+// newTestGRPCClient starts an in-process gRPC server backed by a bufconn
+// listener, running fake, and returns a GRPCClient dialed to it with cfg.
+// Bypasses NewGRPCClient's grpc.Dial (which needs a real address) since
+// GRPCClient's fields are all reachable from this in-package test.
+func newTestGRPCClient(t *testing.T, cfg OutputGRPC, fake *fakeFlowCollector) *GRPCClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	pbflow.RegisterFlowCollectorServer(server, fake)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial bufconn: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.QueueLength <= 0 {
+		cfg.QueueLength = 10 * cfg.BatchSize
+	}
+	if cfg.Backpressure == "" {
+		cfg.Backpressure = "dropOldest"
+	}
+	return &GRPCClient{
+		config: cfg,
+		client: pbflow.NewFlowCollectorClient(conn),
+		conn:   conn,
+	}
+}
+
+func flowWithID(id uint64) *pbflow.Flow {
+	return &pbflow.Flow{OctetDeltaCount: id}
+}
+
+func TestGRPCClientSendFlushesOnceBatchSizeReached(t *testing.T) {
+	fake := &fakeFlowCollector{}
+	c := newTestGRPCClient(t, OutputGRPC{BatchSize: 2, QueueLength: 100}, fake)
+
+	ctx := context.Background()
+	if err := c.Send(ctx, flowWithID(1)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if got := fake.received(); len(got) != 0 {
+		t.Fatalf("expected no flush before BatchSize is reached, got %d batches", len(got))
+	}
+	if err := c.Send(ctx, flowWithID(2)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	got := fake.received()
+	if len(got) != 1 || len(got[0]) != 2 {
+		t.Fatalf("expected one batch of 2 flows, got %v", got)
+	}
+}
+
+func TestGRPCClientFlushSendsPartialBatch(t *testing.T) {
+	fake := &fakeFlowCollector{}
+	c := newTestGRPCClient(t, OutputGRPC{BatchSize: 100, QueueLength: 100}, fake)
+
+	ctx := context.Background()
+	if err := c.Send(ctx, flowWithID(1)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if err := c.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	got := fake.received()
+	if len(got) != 1 || len(got[0]) != 1 || got[0][0].OctetDeltaCount != 1 {
+		t.Fatalf("expected one batch with the single queued flow, got %v", got)
+	}
+
+	// A second Flush with nothing queued must be a no-op, not an empty RPC.
+	if err := c.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got := fake.received(); len(got) != 1 {
+		t.Fatalf("expected Flush on an empty queue not to send anything, got %v", got)
+	}
+}
+
+func TestGRPCClientSendDropsOldestWhenQueueFull(t *testing.T) {
+	fake := &fakeFlowCollector{}
+	c := newTestGRPCClient(t, OutputGRPC{
+		BatchSize:    100,
+		QueueLength:  2,
+		Backpressure: "dropOldest",
+	}, fake)
+
+	ctx := context.Background()
+	for _, id := range []uint64{1, 2, 3} {
+		if err := c.Send(ctx, flowWithID(id)); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	if err := c.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	got := fake.received()
+	if len(got) != 1 || len(got[0]) != 2 {
+		t.Fatalf("expected one batch of 2 flows, got %v", got)
+	}
+	if got[0][0].OctetDeltaCount != 2 || got[0][1].OctetDeltaCount != 3 {
+		t.Fatalf("expected flow 1 to have been dropped, kept %v", got[0])
+	}
+}
+
+func TestGRPCClientSendBlocksAndFlushesWhenQueueFull(t *testing.T) {
+	fake := &fakeFlowCollector{}
+	c := newTestGRPCClient(t, OutputGRPC{
+		BatchSize:    100,
+		QueueLength:  2,
+		Backpressure: "block",
+	}, fake)
+
+	ctx := context.Background()
+	for _, id := range []uint64{1, 2, 3} {
+		if err := c.Send(ctx, flowWithID(id)); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	got := fake.received()
+	if len(got) != 1 || len(got[0]) != 2 {
+		t.Fatalf("expected the first two flows to have been flushed synchronously, got %v", got)
+	}
+	if got[0][0].OctetDeltaCount != 1 || got[0][1].OctetDeltaCount != 2 {
+		t.Fatalf("expected flows 1 and 2 in the blocking flush, got %v", got[0])
+	}
+
+	if err := c.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	got = fake.received()
+	if len(got) != 2 || len(got[1]) != 1 || got[1][0].OctetDeltaCount != 3 {
+		t.Fatalf("expected flow 3 in a second batch after Flush, got %v", got)
+	}
+}
+
+func TestBuildTLSConfigSetsServerNameAndSkipVerify(t *testing.T) {
+	tlsConfig, err := buildTLSConfig(&OutputGRPCTLS{
+		ServerName:         "collector.example.com",
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		t.Fatalf("buildTLSConfig: %v", err)
+	}
+	if tlsConfig.ServerName != "collector.example.com" {
+		t.Fatalf("got ServerName %q", tlsConfig.ServerName)
+	}
+	if !tlsConfig.InsecureSkipVerify {
+		t.Fatal("expected InsecureSkipVerify to be carried through")
+	}
+}
+
+func TestBuildTLSConfigErrorsOnMissingCAFile(t *testing.T) {
+	_, err := buildTLSConfig(&OutputGRPCTLS{CA: filepath.Join(t.TempDir(), "does-not-exist.pem")})
+	if err == nil {
+		t.Fatal("expected an error for a missing CA file")
+	}
+}
+
+func TestBuildTLSConfigErrorsOnInvalidCAFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(path, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("write ca file: %v", err)
+	}
+	if _, err := buildTLSConfig(&OutputGRPCTLS{CA: path}); err == nil {
+		t.Fatal("expected an error for a CA file with no valid certificates")
+	}
+}
+
+func TestBuildTLSConfigErrorsOnMissingClientCert(t *testing.T) {
+	dir := t.TempDir()
+	_, err := buildTLSConfig(&OutputGRPCTLS{
+		Cert: filepath.Join(dir, "does-not-exist.crt"),
+		Key:  filepath.Join(dir, "does-not-exist.key"),
+	})
+	if err == nil {
+		t.Fatal("expected an error for a missing client cert/key pair")
+	}
+}
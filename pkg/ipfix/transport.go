@@ -0,0 +1,350 @@
+/*
+Copyright 2022 Hiroki Shirokura.
+Copyright 2022 Keio University.
+Copyright 2022 Wide Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipfix
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	transportReconnectsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "flow_exporter",
+		Subsystem: "ipfix_transport",
+		Name:      "reconnects_total",
+		Help:      "Number of times the IPFIX collector connection (tcp/tls) was re-established.",
+	})
+	transportBytesSentTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "flow_exporter",
+		Subsystem: "ipfix_transport",
+		Name:      "bytes_sent_total",
+		Help:      "Bytes of IPFIX messages written to the collector connection (tcp/tls).",
+	})
+	transportDroppedRecordsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "flow_exporter",
+		Subsystem: "ipfix_transport",
+		Name:      "dropped_records_total",
+		Help:      "IPFIX messages dropped from the send queue (drop-oldest) while disconnected.",
+	})
+	transportTemplateRetransmitsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "flow_exporter",
+		Subsystem: "ipfix_transport",
+		Name:      "template_retransmits_total",
+		Help:      "Number of times the IPFIX template set was retransmitted on (re)connect.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		transportReconnectsTotal,
+		transportBytesSentTotal,
+		transportDroppedRecordsTotal,
+		transportTemplateRetransmitsTotal,
+	)
+}
+
+// StreamTransport sends IPFIX messages over a single reconnecting TCP or
+// TLS connection (RFC 5153), as an alternative to OutputCollector's default
+// UDP/MTU-fragmentation model. Per-message size is still capped at
+// Config.MaxIpfixMessageLen for collector compatibility, but messages are
+// written back-to-back as a continuous length-prefixed stream rather than
+// being bound by a single datagram's MTU.
+type StreamTransport struct {
+	config   OutputCollector
+	queue    *boundedQueue
+	template func() ([]byte, error)
+
+	mu       sync.Mutex
+	conn     net.Conn
+	seq      uint32
+	backoff  time.Duration
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+const (
+	minReconnectBackoff = 500 * time.Millisecond
+	maxReconnectBackoff = 30 * time.Second
+
+	// defaultQueueLength is used when NewStreamTransport is called with
+	// queueLength <= 0.
+	defaultQueueLength = 1000
+)
+
+// NewStreamTransport starts a background connection loop to cfg.RemoteAddress
+// using cfg.Transport ("tcp" or "tls"). queueLength bounds how many pending
+// messages may be buffered while disconnected; once full, the oldest queued
+// message is dropped to make room for the new one. queueLength defaults to
+// defaultQueueLength when <= 0. templateFn returns the current IPFIX
+// template message bytes, and is called to retransmit templates on every
+// (re)connect, independent of the template flush timer.
+func NewStreamTransport(cfg OutputCollector, queueLength int,
+	templateFn func() ([]byte, error)) (*StreamTransport, error) {
+
+	if cfg.transport() != "tcp" && cfg.transport() != "tls" {
+		return nil, fmt.Errorf("unsupported stream transport %q", cfg.Transport)
+	}
+	if queueLength <= 0 {
+		queueLength = defaultQueueLength
+	}
+	t := &StreamTransport{
+		config:   cfg,
+		queue:    newBoundedQueue(queueLength),
+		template: templateFn,
+		stopCh:   make(chan struct{}),
+	}
+	go t.run()
+	return t, nil
+}
+
+// Send enqueues an already-serialized IPFIX message for delivery. It never
+// blocks: once the queue is full, the oldest pending message is dropped.
+func (t *StreamTransport) Send(b []byte) {
+	if dropped := t.queue.push(b); dropped {
+		transportDroppedRecordsTotal.Inc()
+	}
+}
+
+// NextSequenceNumber returns the next IPFIX sequence number to use,
+// guaranteeing continuity across reconnects (the counter isn't reset when
+// the connection drops).
+func (t *StreamTransport) NextSequenceNumber(n uint32) uint32 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	seq := t.seq
+	t.seq += n
+	return seq
+}
+
+func (t *StreamTransport) Close() {
+	t.stopOnce.Do(func() {
+		close(t.stopCh)
+		t.queue.close()
+	})
+}
+
+func (t *StreamTransport) run() {
+	for {
+		select {
+		case <-t.stopCh:
+			return
+		default:
+		}
+
+		conn, err := t.dial()
+		if err != nil {
+			t.sleepBackoff()
+			continue
+		}
+		transportReconnectsTotal.Inc()
+
+		if err := t.retransmitTemplate(conn); err != nil {
+			conn.Close()
+			t.sleepBackoff()
+			continue
+		}
+		t.backoff = 0
+
+		t.drainTo(conn)
+	}
+}
+
+func (t *StreamTransport) dial() (net.Conn, error) {
+	addr := t.config.RemoteAddress
+	if t.config.transport() == "tls" {
+		tlsConfig, err := t.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		return tls.Dial("tcp", addr, tlsConfig)
+	}
+	return net.Dial("tcp", addr)
+}
+
+func (t *StreamTransport) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{}
+	if t.config.TLS == nil {
+		return cfg, nil
+	}
+	cfg.ServerName = t.config.TLS.ServerName
+	cfg.InsecureSkipVerify = t.config.TLS.InsecureSkipVerify
+	if t.config.TLS.CA != "" {
+		pem, err := os.ReadFile(t.config.TLS.CA)
+		if err != nil {
+			return nil, fmt.Errorf("read ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", t.config.TLS.CA)
+		}
+		cfg.RootCAs = pool
+	}
+	if t.config.TLS.Cert != "" && t.config.TLS.Key != "" {
+		cert, err := tls.LoadX509KeyPair(t.config.TLS.Cert, t.config.TLS.Key)
+		if err != nil {
+			return nil, fmt.Errorf("load client cert: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// retransmitTemplate sends the current template set immediately on
+// (re)connect, so a fresh collector session doesn't have to wait for the
+// next TimerTemplateFlushSeconds tick before it can decode data records.
+func (t *StreamTransport) retransmitTemplate(conn net.Conn) error {
+	b, err := t.template()
+	if err != nil {
+		return err
+	}
+	if err := writeFramed(conn, b); err != nil {
+		return err
+	}
+	transportTemplateRetransmitsTotal.Inc()
+	return nil
+}
+
+// drainTo writes queued messages to conn until either the connection fails
+// or Close is called.
+func (t *StreamTransport) drainTo(conn net.Conn) {
+	defer conn.Close()
+	for {
+		select {
+		case <-t.stopCh:
+			return
+		default:
+		}
+
+		b, ok := t.queue.pop()
+		if !ok {
+			return
+		}
+		if err := writeFramed(conn, b); err != nil {
+			t.queue.pushFront(b)
+			return
+		}
+	}
+}
+
+func (t *StreamTransport) sleepBackoff() {
+	if t.backoff == 0 {
+		t.backoff = minReconnectBackoff
+	} else {
+		t.backoff *= 2
+		if t.backoff > maxReconnectBackoff {
+			t.backoff = maxReconnectBackoff
+		}
+	}
+	select {
+	case <-time.After(t.backoff):
+	case <-t.stopCh:
+	}
+}
+
+// writeFramed writes b as a 4-byte big-endian length prefix followed by b,
+// so a stream of back-to-back IPFIX messages can be split back into
+// individual messages on the collector side without relying on datagram
+// boundaries.
+func writeFramed(conn net.Conn, b []byte) error {
+	var lenPrefix [4]byte
+	binary.BigEndian.PutUint32(lenPrefix[:], uint32(len(b)))
+	if _, err := conn.Write(lenPrefix[:]); err != nil {
+		return err
+	}
+	n, err := conn.Write(b)
+	if err != nil {
+		return err
+	}
+	transportBytesSentTotal.Add(float64(n + len(lenPrefix)))
+	return nil
+}
+
+// boundedQueue is a FIFO byte-slice queue with a fixed capacity. Once full,
+// pushing a new item drops the oldest one (drop-oldest backpressure).
+type boundedQueue struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	items    [][]byte
+	capacity int
+	closed   bool
+}
+
+func newBoundedQueue(capacity int) *boundedQueue {
+	if capacity <= 0 {
+		// A non-positive capacity would make push's len(q.items) >=
+		// q.capacity check fire on every call, reporting every push as a
+		// drop even though the item is still enqueued.
+		capacity = defaultQueueLength
+	}
+	q := &boundedQueue{capacity: capacity}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *boundedQueue) push(b []byte) (dropped bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) >= q.capacity {
+		q.items = q.items[1:]
+		dropped = true
+	}
+	q.items = append(q.items, b)
+	q.cond.Signal()
+	return dropped
+}
+
+// pushFront re-queues a message that failed to send, so it's retried first
+// on the next connection.
+func (q *boundedQueue) pushFront(b []byte) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.items = append([][]byte{b}, q.items...)
+	q.cond.Signal()
+}
+
+// close wakes any blocked pop so it can observe that the queue is done.
+func (q *boundedQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// pop blocks until an item is available or the queue is closed.
+func (q *boundedQueue) pop() ([]byte, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.items) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.items) == 0 {
+		return nil, false
+	}
+	b := q.items[0]
+	q.items = q.items[1:]
+	return b, true
+}
@@ -0,0 +1,271 @@
+/*
+Copyright 2022 Hiroki Shirokura.
+Copyright 2022 Keio University.
+Copyright 2022 Wide Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipfix
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBoundedQueueFIFOAndDropOldest(t *testing.T) {
+	q := newBoundedQueue(2)
+
+	if dropped := q.push([]byte("a")); dropped {
+		t.Fatal("unexpected drop on first push")
+	}
+	if dropped := q.push([]byte("b")); dropped {
+		t.Fatal("unexpected drop on second push")
+	}
+	if dropped := q.push([]byte("c")); !dropped {
+		t.Fatal("expected the third push to drop the oldest item")
+	}
+
+	b, ok := q.pop()
+	if !ok || string(b) != "b" {
+		t.Fatalf("got %q, ok=%v, want \"b\"", b, ok)
+	}
+	b, ok = q.pop()
+	if !ok || string(b) != "c" {
+		t.Fatalf("got %q, ok=%v, want \"c\"", b, ok)
+	}
+}
+
+func TestBoundedQueuePushFrontTakesPriority(t *testing.T) {
+	q := newBoundedQueue(2)
+	q.push([]byte("a"))
+	q.pushFront([]byte("retry"))
+
+	b, ok := q.pop()
+	if !ok || string(b) != "retry" {
+		t.Fatalf("got %q, ok=%v, want \"retry\" to be popped first", b, ok)
+	}
+}
+
+func TestBoundedQueueCloseUnblocksPop(t *testing.T) {
+	q := newBoundedQueue(2)
+	done := make(chan struct{})
+	go func() {
+		_, ok := q.pop()
+		if ok {
+			t.Error("expected pop to report !ok after close")
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("pop returned before close, should have blocked on an empty queue")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	q.close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("close did not unblock pop")
+	}
+}
+
+// TestNewBoundedQueueDefaultsNonPositiveCapacity guards the bug the review
+// caught: with capacity 0, push's len(q.items) >= q.capacity check is always
+// true, so every push reported a spurious drop even though the item was
+// still enqueued.
+func TestNewBoundedQueueDefaultsNonPositiveCapacity(t *testing.T) {
+	for _, capacity := range []int{0, -1} {
+		q := newBoundedQueue(capacity)
+		if dropped := q.push([]byte("a")); dropped {
+			t.Fatalf("capacity %d: first push reported a drop", capacity)
+		}
+		b, ok := q.pop()
+		if !ok || string(b) != "a" {
+			t.Fatalf("capacity %d: got %q, ok=%v, want \"a\"", capacity, b, ok)
+		}
+	}
+}
+
+func TestNewStreamTransportDefaultsNonPositiveQueueLength(t *testing.T) {
+	tr, err := NewStreamTransport(OutputCollector{
+		RemoteAddress: "127.0.0.1:0",
+		Transport:     "tcp",
+	}, 0, func() ([]byte, error) { return nil, nil })
+	if err != nil {
+		t.Fatalf("NewStreamTransport: %v", err)
+	}
+	defer tr.Close()
+
+	if tr.queue.capacity != defaultQueueLength {
+		t.Fatalf("got queue capacity %d, want defaultQueueLength (%d)", tr.queue.capacity, defaultQueueLength)
+	}
+}
+
+func TestSleepBackoffGrowsAndCaps(t *testing.T) {
+	// Pre-closing stopCh makes sleepBackoff's select return immediately via
+	// the stopCh case instead of actually waiting out the backoff duration,
+	// so this test can assert the growth sequence without taking as long as
+	// it describes.
+	stopCh := make(chan struct{})
+	close(stopCh)
+	tr := &StreamTransport{stopCh: stopCh}
+
+	want := minReconnectBackoff
+	for i := 0; i < 10; i++ {
+		tr.sleepBackoff()
+		if tr.backoff != want {
+			t.Fatalf("iteration %d: backoff = %s, want %s", i, tr.backoff, want)
+		}
+		want *= 2
+		if want > maxReconnectBackoff {
+			want = maxReconnectBackoff
+		}
+	}
+}
+
+// readFramed reads one writeFramed-encoded message from conn.
+func readFramed(conn net.Conn) ([]byte, error) {
+	var lenPrefix [4]byte
+	if _, err := io.ReadFull(conn, lenPrefix[:]); err != nil {
+		return nil, err
+	}
+	b := make([]byte, binary.BigEndian.Uint32(lenPrefix[:]))
+	if _, err := io.ReadFull(conn, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// TestStreamTransportRetransmitsTemplateAndReconnects is an end-to-end test
+// of StreamTransport.run against a real TCP listener: it checks the
+// template is sent first on every (re)connect (ahead of any queued data),
+// and that a dropped connection is transparently reconnected.
+func TestStreamTransportRetransmitsTemplateAndReconnects(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer lis.Close()
+
+	var templateCalls int32
+	templateFn := func() ([]byte, error) {
+		atomic.AddInt32(&templateCalls, 1)
+		return []byte("template"), nil
+	}
+
+	tr, err := NewStreamTransport(OutputCollector{
+		RemoteAddress: lis.Addr().String(),
+		Transport:     "tcp",
+	}, 10, templateFn)
+	if err != nil {
+		t.Fatalf("NewStreamTransport: %v", err)
+	}
+	defer tr.Close()
+
+	// First connection: expect the template, then a queued data message.
+	conn1, err := lis.Accept()
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	got, err := readFramed(conn1)
+	if err != nil || string(got) != "template" {
+		t.Fatalf("first frame = %q, err=%v, want \"template\"", got, err)
+	}
+
+	tr.Send([]byte("data1"))
+	got, err = readFramed(conn1)
+	if err != nil || string(got) != "data1" {
+		t.Fatalf("second frame = %q, err=%v, want \"data1\"", got, err)
+	}
+
+	// Dropping the connection is only noticed on the next write attempt
+	// (drainTo never reads), so a further Send is needed to surface the
+	// broken pipe and trigger a reconnect with a fresh template retransmit.
+	conn1.Close()
+	tr.Send([]byte("data2"))
+
+	conn2, err := lis.Accept()
+	if err != nil {
+		t.Fatalf("accept after reconnect: %v", err)
+	}
+	defer conn2.Close()
+
+	got, err = readFramed(conn2)
+	if err != nil || string(got) != "template" {
+		t.Fatalf("post-reconnect first frame = %q, err=%v, want \"template\"", got, err)
+	}
+	if calls := atomic.LoadInt32(&templateCalls); calls < 2 {
+		t.Fatalf("templateFn called %d times, want at least 2 (initial connect + reconnect)", calls)
+	}
+}
+
+// TestStreamTransportRetriesWhenTemplateRetransmitFails is a regression
+// test for 41e547e: backoff must not be reset to 0 until retransmitTemplate
+// has actually succeeded, and a retransmit failure must still sleep the
+// backoff rather than spinning into an immediate redial.
+func TestStreamTransportRetriesWhenTemplateRetransmitFails(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer lis.Close()
+
+	var attempts int32
+	templateFn := func() ([]byte, error) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			return nil, errors.New("template not ready yet")
+		}
+		return []byte("template"), nil
+	}
+
+	tr, err := NewStreamTransport(OutputCollector{
+		RemoteAddress: lis.Addr().String(),
+		Transport:     "tcp",
+	}, 10, templateFn)
+	if err != nil {
+		t.Fatalf("NewStreamTransport: %v", err)
+	}
+	defer tr.Close()
+
+	// The first accepted connection's retransmit fails, so run() must close
+	// it without writing anything, back off, and redial for a second
+	// connection whose retransmit succeeds.
+	conn1, err := lis.Accept()
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	conn1.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if _, err := readFramed(conn1); err == nil {
+		t.Fatal("expected the first connection to be closed without any data, since templateFn errored")
+	}
+	conn1.Close()
+
+	conn2, err := lis.Accept()
+	if err != nil {
+		t.Fatalf("accept after retransmit-failure retry: %v", err)
+	}
+	defer conn2.Close()
+
+	got, err := readFramed(conn2)
+	if err != nil || string(got) != "template" {
+		t.Fatalf("second-connection first frame = %q, err=%v, want \"template\"", got, err)
+	}
+}